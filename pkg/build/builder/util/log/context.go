@@ -0,0 +1,25 @@
+package log
+
+import "context"
+
+// contextKey is unexported to prevent collisions with keys from other packages.
+type contextKey struct{}
+
+var loggerKey = contextKey{}
+
+// NewContext returns a copy of ctx carrying logger, so that build steps can attach
+// stable fields (build id, stage, image ref, layer digest) once with WithValues
+// and have them emitted on every subsequent InfoS and ErrorS line (the klog and
+// file loggers do not add them to Infof output).
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the Logger attached to ctx by NewContext, or None if ctx
+// carries no logger.
+func FromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerKey).(Logger); ok {
+		return logger
+	}
+	return None
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInfofCtxSkipsOnceCancelled(t *testing.T) {
+	fake := newFakeLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fake.InfofCtx(ctx, "should not print: %d", 1)
+
+	if got := fake.calls(); len(got) != 0 {
+		t.Errorf("InfofCtx on a cancelled context logged %v, want nothing", got)
+	}
+}
+
+func TestInfofCtxLogsWhenNotCancelled(t *testing.T) {
+	fake := newFakeLogger()
+	fake.InfofCtx(context.Background(), "hello %s", "world")
+
+	want := []string{"hello world"}
+	if got := fake.calls(); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("InfofCtx on a live context logged %v, want %v", got, want)
+	}
+}
+
+func TestFromContextAndNewContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != None {
+		t.Errorf("FromContext(background) = %v, want the None sentinel", got)
+	}
+
+	fake := newFakeLogger()
+	ctx := NewContext(context.Background(), fake)
+	if got := FromContext(ctx); got != Logger(fake) {
+		t.Errorf("FromContext(NewContext(ctx, fake)) = %v, want fake", got)
+	}
+}
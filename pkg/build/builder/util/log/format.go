@@ -0,0 +1,158 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format selects how a file logger renders its records.
+type Format int
+
+const (
+	// FormatText renders records as a plain, human readable line (the historical
+	// behavior of this package).
+	FormatText Format = iota
+	// FormatJSON renders records as one JSON object per line.
+	FormatJSON
+	// FormatLogfmt renders records as logfmt (key=value) lines.
+	FormatLogfmt
+)
+
+// writeRecord renders msg and the key/value pairs according to f.format and
+// writes the result to f.w. For FormatText, prefix/name handling matches the
+// original Infof/InfoS behavior; for FormatJSON and FormatLogfmt the record
+// includes ts, level, v, msg, caller, and the key/value pairs.
+func (f file) writeRecord(isError bool, err error, msg string, keysAndValues ...interface{}) {
+	if isError && err != nil {
+		keysAndValues = append([]interface{}{"err", err.Error()}, keysAndValues...)
+	}
+
+	switch f.format {
+	case FormatJSON:
+		io.WriteString(f.w, f.renderJSON(isError, msg, keysAndValues))
+	case FormatLogfmt:
+		io.WriteString(f.w, f.renderLogfmt(isError, msg, keysAndValues))
+	default:
+		io.WriteString(f.w, f.renderText(isError, msg, keysAndValues))
+	}
+}
+
+func (f file) renderText(isError bool, msg string, keysAndValues []interface{}) string {
+	var b strings.Builder
+	if isError {
+		b.WriteString("ERROR: ")
+	}
+	if f.name != "" {
+		b.WriteString(f.name)
+		b.WriteString(": ")
+	}
+	b.WriteString(msg)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", keysAndValues[i], keysAndValues[i+1])
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// jsonReservedKeys are the record's own metadata fields. A caller-supplied
+// key/value pair matching one of them would otherwise silently overwrite
+// that metadata, so it is renamed with a "user_" prefix instead.
+var jsonReservedKeys = map[string]bool{
+	"ts": true, "level": true, "v": true, "msg": true, "caller": true, "logger": true,
+}
+
+func (f file) renderJSON(isError bool, msg string, keysAndValues []interface{}) string {
+	record := map[string]interface{}{
+		"ts":     time.Now().UTC().Format(time.RFC3339Nano),
+		"level":  recordLevel(isError),
+		"v":      f.v,
+		"msg":    msg,
+		"caller": caller(),
+	}
+	if f.name != "" {
+		record["logger"] = f.name
+	}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			continue
+		}
+		if jsonReservedKeys[key] {
+			key = "user_" + key
+		}
+		record[key] = keysAndValues[i+1]
+	}
+	out, err := json.Marshal(record)
+	if err != nil {
+		return f.renderText(isError, msg, keysAndValues)
+	}
+	return string(out) + "\n"
+}
+
+func (f file) renderLogfmt(isError bool, msg string, keysAndValues []interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ts=%s level=%s v=%d", time.Now().UTC().Format(time.RFC3339Nano), recordLevel(isError), f.v)
+	if f.name != "" {
+		fmt.Fprintf(&b, " logger=%s", logfmtValue(f.name))
+	}
+	fmt.Fprintf(&b, " msg=%s caller=%s", logfmtValue(msg), logfmtValue(caller()))
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fmt.Fprintf(&b, " %v=%s", keysAndValues[i], logfmtValue(fmt.Sprintf("%v", keysAndValues[i+1])))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func recordLevel(isError bool) string {
+	if isError {
+		return "error"
+	}
+	return "info"
+}
+
+// logfmtValue quotes value per the usual logfmt escaping rules: bare if it is
+// non-empty and contains no spaces, quotes, '=' or characters below U+0020,
+// quoted otherwise.
+func logfmtValue(value string) string {
+	needsQuote := value == ""
+	for _, r := range value {
+		if r <= ' ' || r == '"' || r == '=' {
+			needsQuote = true
+			break
+		}
+	}
+	if !needsQuote {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// packageDir is the directory containing this file, used by caller to skip
+// past every frame inside this package: writeRecord itself, any number of
+// Tee/RateLimited/Sampled/named wrapper hops, and whichever file they're
+// defined in.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// caller returns "file:line" for the first stack frame outside this package.
+func caller() string {
+	for skip := 2; skip < 32; skip++ {
+		_, file, line, ok := runtime.Caller(skip)
+		if !ok {
+			break
+		}
+		if filepath.Dir(file) == packageDir {
+			continue
+		}
+		return file + ":" + strconv.Itoa(line)
+	}
+	return ""
+}
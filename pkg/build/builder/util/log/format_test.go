@@ -0,0 +1,170 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileInfofText(t *testing.T) {
+	var buf bytes.Buffer
+	f := ToFile(&buf, 5)
+	f.Infof("hello %s", "world")
+	if got, want := buf.String(), "hello world\n"; got != want {
+		t.Errorf("Infof() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	var buf bytes.Buffer
+	f := ToFileFormat(&buf, 5, FormatJSON).WithName("dockerclient")
+	f.InfoS("pulling image", "ref", "busybox:latest")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("output is not valid JSON: %v\noutput: %s", err, buf.String())
+	}
+	for _, key := range []string{"ts", "level", "v", "msg", "caller"} {
+		if _, ok := record[key]; !ok {
+			t.Errorf("record missing required key %q: %v", key, record)
+		}
+	}
+	if record["level"] != "info" {
+		t.Errorf("level = %v, want %q", record["level"], "info")
+	}
+	if record["msg"] != "pulling image" {
+		t.Errorf("msg = %v, want %q", record["msg"], "pulling image")
+	}
+	if record["ref"] != "busybox:latest" {
+		t.Errorf("ref = %v, want %q", record["ref"], "busybox:latest")
+	}
+	if record["logger"] != "dockerclient" {
+		t.Errorf("logger = %v, want %q", record["logger"], "dockerclient")
+	}
+	if caller, ok := record["caller"].(string); !ok || isPackageInternalCaller(caller) {
+		t.Errorf("caller = %v, want it to point outside the log package", record["caller"])
+	}
+}
+
+// isPackageInternalCaller reports whether caller (a "file:line" string) names
+// one of this package's own source files, which would mean caller() failed
+// to skip past an internal wrapper frame.
+func isPackageInternalCaller(caller string) bool {
+	file := caller
+	if i := strings.LastIndex(caller, ":"); i >= 0 {
+		file = caller[:i]
+	}
+	switch filepath.Base(file) {
+	case "log.go", "format.go", "ring.go", "vmodule.go", "ratelimit.go", "context.go":
+		return true
+	default:
+		return false
+	}
+}
+
+// TestCallerSkipsWrapperFrames is a regression test: caller() must walk past
+// every Tee/RateLimited/Sampled/named frame, not just file.writeRecord's own,
+// so JSON/logfmt records keep pointing at the real call site even when the
+// file logger is composed with the combinators the rest of this package
+// provides.
+func TestCallerSkipsWrapperFrames(t *testing.T) {
+	var direct, wrapped bytes.Buffer
+	directLogger := ToFileFormat(&direct, 5, FormatJSON)
+	wrappedLogger := Tee(RateLimited(Sampled(ToFileFormat(&wrapped, 5, FormatJSON), 1), 1000, 1000))
+
+	directLogger.InfoS("hello")
+	wrappedLogger.InfoS("hello")
+
+	var directRecord, wrappedRecord map[string]interface{}
+	if err := json.Unmarshal(direct.Bytes(), &directRecord); err != nil {
+		t.Fatalf("direct output is not valid JSON: %v", err)
+	}
+	if err := json.Unmarshal(wrapped.Bytes(), &wrappedRecord); err != nil {
+		t.Fatalf("wrapped output is not valid JSON: %v", err)
+	}
+
+	wrappedCaller, _ := wrappedRecord["caller"].(string)
+	if isPackageInternalCaller(wrappedCaller) {
+		t.Errorf("wrapped caller = %q, want it to skip past Tee/RateLimited/Sampled frames", wrappedCaller)
+	}
+}
+
+// TestRenderJSONReservedKeyCollision is a regression test: a caller-supplied
+// key/value pair matching one of the record's own metadata fields must not
+// silently overwrite that metadata.
+func TestRenderJSONReservedKeyCollision(t *testing.T) {
+	var buf bytes.Buffer
+	f := ToFileFormat(&buf, 5, FormatJSON)
+	f.InfoS("x", "msg", "spoofed", "level", "error")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("output is not valid JSON: %v\noutput: %s", err, buf.String())
+	}
+	if record["msg"] != "x" {
+		t.Errorf("msg = %v, want %q: a caller-supplied \"msg\" key must not overwrite the record's own message", record["msg"], "x")
+	}
+	if record["level"] != "info" {
+		t.Errorf("level = %v, want %q: a caller-supplied \"level\" key must not overwrite the record's own level", record["level"], "info")
+	}
+	if record["user_msg"] != "spoofed" {
+		t.Errorf("user_msg = %v, want %q: the colliding key should still be preserved under a renamed key", record["user_msg"], "spoofed")
+	}
+	if record["user_level"] != "error" {
+		t.Errorf("user_level = %v, want %q", record["user_level"], "error")
+	}
+}
+
+func TestRenderJSONErrorS(t *testing.T) {
+	var buf bytes.Buffer
+	f := ToFileFormat(&buf, 5, FormatJSON)
+	f.ErrorS(errors.New("boom"), "pull failed")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("output is not valid JSON: %v\noutput: %s", err, buf.String())
+	}
+	if record["level"] != "error" {
+		t.Errorf("level = %v, want %q", record["level"], "error")
+	}
+	if record["err"] != "boom" {
+		t.Errorf("err = %v, want %q", record["err"], "boom")
+	}
+}
+
+func TestRenderLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	f := ToFileFormat(&buf, 5, FormatLogfmt)
+	f.InfoS("hello world", "k", "v with space")
+
+	out := buf.String()
+	for _, want := range []string{`msg="hello world"`, `k="v with space"`, "level=info"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+	if !strings.Contains(out, "caller=") || strings.Contains(out, "caller=\"\"") {
+		t.Errorf("output %q has no usable caller field", out)
+	}
+}
+
+func TestLogfmtValueEscaping(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"bare", "bare"},
+		{"", `""`},
+		{"has space", `"has space"`},
+		{`has"quote`, `"has\"quote"`},
+		{"has=equals", `"has=equals"`},
+	}
+	for _, c := range cases {
+		if got := logfmtValue(c.in); got != c.want {
+			t.Errorf("logfmtValue(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
@@ -1,24 +1,60 @@
 package log
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
 
-	"k8s.io/klog"
+	"k8s.io/klog/v2"
 )
 
-// Logger is a simple interface that is roughly equivalent to klog.
+// Logger is a simple interface that is roughly equivalent to klog, extended with
+// the structured, key/value based logging calls that klog/v2 exposes through logr.
 type Logger interface {
 	Is(level int) bool
 	V(level int) Logger
 	Infof(format string, args ...interface{})
+	// InfofCtx is Infof, but does nothing once ctx is done, so that cancelled
+	// builds stop spending CPU formatting messages no one will read.
+	InfofCtx(ctx context.Context, format string, args ...interface{})
+
+	// InfoS logs a non-error message with the given key/value pairs as context, in
+	// the style of logr.Logger.Info.
+	InfoS(msg string, keysAndValues ...interface{})
+	// ErrorS logs an error with the given key/value pairs as context, in the style
+	// of logr.Logger.Error. err may be nil.
+	ErrorS(err error, msg string, keysAndValues ...interface{})
+	// WithValues returns a Logger that will include the given key/value pairs with
+	// every subsequent InfoS and ErrorS call. The klog and file loggers do not add
+	// them to Infof or InfofCtx output.
+	WithValues(keysAndValues ...interface{}) Logger
+	// WithName returns a Logger that prefixes its messages with the given name,
+	// nesting under any existing name. The JSON and logfmt file formats carry the
+	// name in a "logger" field instead of a prefix.
+	WithName(name string) Logger
+}
+
+// infofCtx calls l.Infof(format, args...) unless ctx is already done.
+func infofCtx(l Logger, ctx context.Context, format string, args ...interface{}) {
+	if ctx.Err() != nil {
+		return
+	}
+	l.Infof(format, args...)
 }
 
 // ToFile creates a logger that will log any items at level or below to file, and defer
-// any other output to klog (no matter what the level is.)
+// any other output to klog (no matter what the level is.) Output is rendered as
+// plain text; use ToFileFormat to select a machine-readable format.
 func ToFile(w io.Writer, level int) Logger {
-	return file{w, level}
+	return file{w: w, level: level, format: FormatText}
+}
+
+// ToFileFormat is like ToFile but renders records in the given Format, so that
+// builder logs streamed to a file or container stdout can be consumed by log
+// aggregators (fluentd, vector, ...) without regex scraping.
+func ToFileFormat(w io.Writer, level int, format Format) Logger {
+	return file{w: w, level: level, format: format}
 }
 
 var (
@@ -31,73 +67,175 @@ var (
 // discard is a Logger that outputs nothing.
 type discard struct{}
 
-func (discard) Is(level int) bool                { return false }
-func (discard) V(level int) Logger               { return None }
-func (discard) Infof(_ string, _ ...interface{}) {}
+func (discard) Is(level int) bool                                      { return false }
+func (discard) V(level int) Logger                                     { return None }
+func (discard) Infof(_ string, _ ...interface{})                       {}
+func (discard) InfofCtx(_ context.Context, _ string, _ ...interface{}) {}
+func (discard) InfoS(_ string, _ ...interface{})                       {}
+func (discard) ErrorS(_ error, _ string, _ ...interface{})             {}
+func (discard) WithValues(_ ...interface{}) Logger                     { return None }
+func (discard) WithName(_ string) Logger                               { return None }
 
 // klogger outputs log messages to klog
-type klogger struct{}
+type klogger struct {
+	name      string
+	keyValues []interface{}
+}
+
+func (g klogger) Is(level int) bool {
+	return bool(klog.V(klog.Level(level)).Enabled())
+}
+
+func (g klogger) V(level int) Logger {
+	return kverbose{enabled: klog.V(klog.Level(level)).Enabled(), logger: g}
+}
+
+func (g klogger) Infof(format string, args ...interface{}) {
+	klog.InfoDepth(2, g.prefix()+fmt.Sprintf(format, args...))
+}
 
-func (klogger) Is(level int) bool {
-	return bool(klog.V(klog.Level(level)))
+func (g klogger) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	infofCtx(g, ctx, format, args...)
 }
 
-func (klogger) V(level int) Logger {
-	return kverbose{klog.V(klog.Level(level))}
+func (g klogger) InfoS(msg string, keysAndValues ...interface{}) {
+	klog.InfoSDepth(2, g.prefix()+msg, append(append([]interface{}{}, g.keyValues...), keysAndValues...)...)
 }
 
-func (klogger) Infof(format string, args ...interface{}) {
-	klog.InfoDepth(2, fmt.Sprintf(format, args...))
+func (g klogger) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	klog.ErrorSDepth(2, err, g.prefix()+msg, append(append([]interface{}{}, g.keyValues...), keysAndValues...)...)
+}
+
+func (g klogger) WithValues(keysAndValues ...interface{}) Logger {
+	g.keyValues = append(append([]interface{}{}, g.keyValues...), keysAndValues...)
+	return g
+}
+
+func (g klogger) WithName(name string) Logger {
+	if g.name != "" {
+		g.name = g.name + "." + name
+	} else {
+		g.name = name
+	}
+	return g
+}
+
+func (g klogger) prefix() string {
+	if g.name == "" {
+		return ""
+	}
+	return g.name + ": "
 }
 
 // kverbose handles klog.V(x) calls
 type kverbose struct {
-	klog.Verbose
+	enabled bool
+	logger  klogger
 }
 
-func (kverbose) Is(level int) bool {
-	return bool(klog.V(klog.Level(level)))
+func (v kverbose) Is(level int) bool {
+	return bool(klog.V(klog.Level(level)).Enabled())
 }
 
-func (kverbose) V(level int) Logger {
-	if klog.V(klog.Level(level)) {
-		return Log
+func (v kverbose) V(level int) Logger {
+	if klog.V(klog.Level(level)).Enabled() {
+		return v.logger
 	}
 	return None
 }
 
-func (g kverbose) Infof(format string, args ...interface{}) {
-	if g.Verbose {
-		klog.InfoDepth(2, fmt.Sprintf(format, args...))
+func (v kverbose) Infof(format string, args ...interface{}) {
+	if v.enabled {
+		klog.InfoDepth(2, v.logger.prefix()+fmt.Sprintf(format, args...))
 	}
 }
 
+func (v kverbose) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	infofCtx(v, ctx, format, args...)
+}
+
+func (v kverbose) InfoS(msg string, keysAndValues ...interface{}) {
+	if v.enabled {
+		v.logger.InfoS(msg, keysAndValues...)
+	}
+}
+
+func (v kverbose) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	v.logger.ErrorS(err, msg, keysAndValues...)
+}
+
+func (v kverbose) WithValues(keysAndValues ...interface{}) Logger {
+	return v.logger.WithValues(keysAndValues...)
+}
+
+func (v kverbose) WithName(name string) Logger {
+	return v.logger.WithName(name)
+}
+
 // file logs the provided messages at level or below to the writer, or delegates
 // to klog.
 type file struct {
-	w     io.Writer
-	level int
+	w         io.Writer
+	level     int
+	v         int
+	format    Format
+	name      string
+	keyValues []interface{}
 }
 
 func (f file) Is(level int) bool {
-	return level <= f.level || bool(klog.V(klog.Level(level)))
+	return level <= f.level || bool(klog.V(klog.Level(level)).Enabled())
 }
 
 func (f file) V(level int) Logger {
 	// only log things that klog allows
-	if !klog.V(klog.Level(level)) {
+	if !klog.V(klog.Level(level)).Enabled() {
 		return None
 	}
 	// send anything above our level to klog
 	if level > f.level {
 		return Log
 	}
+	f.v = level
 	return f
 }
 
 func (f file) Infof(format string, args ...interface{}) {
+	if f.format != FormatText {
+		f.writeRecord(false, nil, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+		return
+	}
+	if f.name != "" {
+		io.WriteString(f.w, f.name+": ")
+	}
 	fmt.Fprintf(f.w, format, args...)
 	if !strings.HasSuffix(format, "\n") {
 		fmt.Fprintln(f.w)
 	}
 }
+
+func (f file) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	infofCtx(f, ctx, format, args...)
+}
+
+func (f file) InfoS(msg string, keysAndValues ...interface{}) {
+	f.writeRecord(false, nil, msg, append(append([]interface{}{}, f.keyValues...), keysAndValues...)...)
+}
+
+func (f file) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	f.writeRecord(true, err, msg, append(append([]interface{}{}, f.keyValues...), keysAndValues...)...)
+}
+
+func (f file) WithValues(keysAndValues ...interface{}) Logger {
+	f.keyValues = append(append([]interface{}{}, f.keyValues...), keysAndValues...)
+	return f
+}
+
+func (f file) WithName(name string) Logger {
+	if f.name != "" {
+		f.name = f.name + "." + name
+	} else {
+		f.name = name
+	}
+	return f
+}
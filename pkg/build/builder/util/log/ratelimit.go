@@ -0,0 +1,178 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimited wraps inner in a Logger that allows at most perSecond messages
+// per second, with an initial burst of up to burst messages, dropping any
+// message over that rate and periodically emitting a summary of how many
+// were suppressed. ErrorS is never rate limited: it passes straight through
+// to inner, since build failures must stay visible regardless of how much
+// Infof/InfoS spam preceded them. It is safe for concurrent use, and
+// delegates Is/V directly to inner so that gating stays cheap when the
+// underlying level is disabled.
+func RateLimited(inner Logger, perSecond int, burst int) Logger {
+	return rateLimited{
+		inner:  inner,
+		bucket: newTokenBucket(float64(perSecond), float64(burst)),
+	}
+}
+
+type rateLimited struct {
+	inner  Logger
+	bucket *tokenBucket
+}
+
+func (r rateLimited) Is(level int) bool { return r.inner.Is(level) }
+
+func (r rateLimited) V(level int) Logger {
+	if !r.inner.Is(level) {
+		return None
+	}
+	return rateLimited{inner: r.inner.V(level), bucket: r.bucket}
+}
+
+func (r rateLimited) Infof(format string, args ...interface{}) {
+	if !r.bucket.allow(r.inner) {
+		return
+	}
+	r.inner.Infof(format, args...)
+}
+
+func (r rateLimited) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	infofCtx(r, ctx, format, args...)
+}
+
+func (r rateLimited) InfoS(msg string, keysAndValues ...interface{}) {
+	if !r.bucket.allow(r.inner) {
+		return
+	}
+	r.inner.InfoS(msg, keysAndValues...)
+}
+
+// ErrorS is never rate limited; see the RateLimited doc comment.
+func (r rateLimited) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	r.inner.ErrorS(err, msg, keysAndValues...)
+}
+
+func (r rateLimited) WithValues(keysAndValues ...interface{}) Logger {
+	return rateLimited{inner: r.inner.WithValues(keysAndValues...), bucket: r.bucket}
+}
+
+func (r rateLimited) WithName(name string) Logger {
+	return rateLimited{inner: r.inner.WithName(name), bucket: r.bucket}
+}
+
+// tokenBucket is a simple, mutex-guarded token bucket shared by a
+// rateLimited Logger and every Logger derived from it via V, so the rate is
+// enforced across all verbosity levels together.
+type tokenBucket struct {
+	mu         sync.Mutex
+	rate       float64
+	burst      float64
+	tokens     float64
+	last       time.Time
+	suppressed int64
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Time{}}
+}
+
+// allow reports whether the caller may emit now, consuming a token if so. If
+// this call is allowed and earlier calls were suppressed, it first logs a
+// "N messages suppressed" summary through logger.
+func (b *tokenBucket) allow(logger Logger) bool {
+	b.mu.Lock()
+	now := time.Now()
+	if b.last.IsZero() {
+		b.last = now
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		b.suppressed++
+		b.mu.Unlock()
+		return false
+	}
+	b.tokens--
+	suppressed := b.suppressed
+	b.suppressed = 0
+	b.mu.Unlock()
+
+	if suppressed > 0 {
+		logger.Infof("%d messages suppressed", suppressed)
+	}
+	return true
+}
+
+// Sampled wraps inner in a Logger that deterministically emits only every
+// Nth call (N == every), so high-frequency events (tar streaming, layer
+// extraction, image progress) can be logged at a fixed rate instead of for
+// every occurrence. ErrorS is never sampled: it passes straight through to
+// inner, since build failures must stay visible regardless of the sampling
+// rate applied to the info spam around them. It is safe for concurrent use.
+func Sampled(inner Logger, every int) Logger {
+	if every < 1 {
+		every = 1
+	}
+	return sampled{inner: inner, every: int64(every), counter: new(int64)}
+}
+
+type sampled struct {
+	inner   Logger
+	every   int64
+	counter *int64
+}
+
+func (s sampled) Is(level int) bool { return s.inner.Is(level) }
+
+func (s sampled) V(level int) Logger {
+	if !s.inner.Is(level) {
+		return None
+	}
+	return sampled{inner: s.inner.V(level), every: s.every, counter: s.counter}
+}
+
+func (s sampled) sample() bool {
+	return atomic.AddInt64(s.counter, 1)%s.every == 0
+}
+
+func (s sampled) Infof(format string, args ...interface{}) {
+	if !s.sample() {
+		return
+	}
+	s.inner.Infof(format, args...)
+}
+
+func (s sampled) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	infofCtx(s, ctx, format, args...)
+}
+
+func (s sampled) InfoS(msg string, keysAndValues ...interface{}) {
+	if !s.sample() {
+		return
+	}
+	s.inner.InfoS(msg, keysAndValues...)
+}
+
+// ErrorS is never sampled; see the Sampled doc comment.
+func (s sampled) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	s.inner.ErrorS(err, msg, keysAndValues...)
+}
+
+func (s sampled) WithValues(keysAndValues ...interface{}) Logger {
+	return sampled{inner: s.inner.WithValues(keysAndValues...), every: s.every, counter: s.counter}
+}
+
+func (s sampled) WithName(name string) Logger {
+	return sampled{inner: s.inner.WithName(name), every: s.every, counter: s.counter}
+}
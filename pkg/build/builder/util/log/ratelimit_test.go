@@ -0,0 +1,192 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeLogger records every call it receives; unused Logger methods are left
+// to the embedded None so only the ones under test need overriding.
+type fakeLogger struct {
+	Logger
+
+	mu         sync.Mutex
+	infofCalls []string
+	errorCalls []string
+}
+
+func newFakeLogger() *fakeLogger {
+	return &fakeLogger{Logger: None}
+}
+
+func (f *fakeLogger) Infof(format string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.infofCalls = append(f.infofCalls, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeLogger) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	infofCtx(f, ctx, format, args...)
+}
+
+func (f *fakeLogger) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errorCalls = append(f.errorCalls, msg)
+}
+
+func (f *fakeLogger) Is(level int) bool  { return true }
+func (f *fakeLogger) V(level int) Logger { return f }
+
+func (f *fakeLogger) calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.infofCalls...)
+}
+
+func TestTokenBucketAllowsUpToBurstThenSuppresses(t *testing.T) {
+	fake := newFakeLogger()
+	b := newTokenBucket(0, 2) // rate 0: no refill from elapsed time
+
+	if !b.allow(fake) {
+		t.Fatalf("call 1: allow() = false, want true (within burst)")
+	}
+	if !b.allow(fake) {
+		t.Fatalf("call 2: allow() = false, want true (within burst)")
+	}
+	if b.allow(fake) {
+		t.Fatalf("call 3: allow() = true, want false (burst exhausted, rate is 0)")
+	}
+	if b.allow(fake) {
+		t.Fatalf("call 4: allow() = true, want false (burst exhausted, rate is 0)")
+	}
+
+	// Simulate a token becoming available (equivalent to time passing at a
+	// nonzero rate) without depending on real elapsed time in the test.
+	b.mu.Lock()
+	b.tokens = 1
+	b.mu.Unlock()
+
+	if !b.allow(fake) {
+		t.Fatalf("call 5: allow() = false, want true (token refilled)")
+	}
+
+	want := []string{"2 messages suppressed"}
+	if got := fake.calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("suppressed-summary calls = %v, want %v", got, want)
+	}
+}
+
+func TestRateLimitedDropsOverBurst(t *testing.T) {
+	fake := newFakeLogger()
+	rl := RateLimited(fake, 0, 1)
+
+	rl.Infof("one")
+	rl.Infof("two")
+	rl.Infof("three")
+
+	got := fake.calls()
+	want := []string{"one"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Infof calls = %v, want %v (only the first, within burst, should pass)", got, want)
+	}
+}
+
+func TestRateLimitedErrorSBypassesTheBucket(t *testing.T) {
+	fake := newFakeLogger()
+	rl := RateLimited(fake, 0, 1)
+
+	for i := 0; i < 5; i++ {
+		rl.ErrorS(nil, "build failed")
+	}
+	if got := len(fake.errorCalls); got != 5 {
+		t.Errorf("ErrorS calls delivered = %d, want 5: ErrorS must never be rate limited", got)
+	}
+}
+
+func TestRateLimitedVShortCircuitsWhenDisabled(t *testing.T) {
+	disabled := &fakeLogger{Logger: None}
+	// Override Is to report this level as disabled.
+	logger := RateLimited(disabledIs{disabled}, 100, 100)
+	if v := logger.V(5); v != None {
+		t.Errorf("V(5) = %v, want the None sentinel when the underlying level is disabled", v)
+	}
+}
+
+// disabledIs wraps a Logger and reports every level as disabled, so tests
+// can force the "underlying level is disabled" path without depending on
+// klog's global verbosity flag.
+type disabledIs struct {
+	Logger
+}
+
+func (disabledIs) Is(level int) bool { return false }
+
+func TestSampledEmitsEveryNthCall(t *testing.T) {
+	fake := newFakeLogger()
+	s := Sampled(fake, 3)
+
+	for i := 1; i <= 9; i++ {
+		s.Infof("msg %d", i)
+	}
+
+	want := []string{"msg 3", "msg 6", "msg 9"}
+	if got := fake.calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sampled calls = %v, want %v", got, want)
+	}
+}
+
+func TestSampledEveryLessThanOneClampsToOne(t *testing.T) {
+	fake := newFakeLogger()
+	s := Sampled(fake, 0)
+
+	s.Infof("a")
+	s.Infof("b")
+
+	want := []string{"a", "b"}
+	if got := fake.calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v: every < 1 should behave as every == 1", got, want)
+	}
+}
+
+func TestSampledErrorSBypassesSampling(t *testing.T) {
+	fake := newFakeLogger()
+	s := Sampled(fake, 5)
+
+	for i := 0; i < 3; i++ {
+		s.ErrorS(nil, "build failed")
+	}
+	if got := len(fake.errorCalls); got != 3 {
+		t.Errorf("ErrorS calls delivered = %d, want 3: ErrorS must never be sampled", got)
+	}
+}
+
+func TestSampledVShortCircuitsWhenDisabled(t *testing.T) {
+	s := Sampled(disabledIs{None}, 3)
+	if v := s.V(5); v != None {
+		t.Errorf("V(5) = %v, want the None sentinel when the underlying level is disabled", v)
+	}
+}
+
+func TestRateLimitedAndSampledConcurrentUse(t *testing.T) {
+	fake := newFakeLogger()
+	rl := RateLimited(Sampled(fake, 2), 1000, 1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rl.Infof("msg %d", i)
+			rl.ErrorS(nil, "err")
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(fake.errorCalls); got != 50 {
+		t.Errorf("ErrorS calls delivered = %d, want 50", got)
+	}
+}
@@ -0,0 +1,230 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Flusher is implemented by sinks, such as the one returned by RingBuffer,
+// that buffer records internally and only emit them when Flush is called.
+type Flusher interface {
+	Flush()
+}
+
+// ringEntry is a pre-formatted record captured by a ring buffer logger.
+type ringEntry struct {
+	ts      time.Time
+	level   int
+	isError bool
+	err     error
+	msg     string
+	kv      []interface{}
+}
+
+// RingBuffer returns a Logger that keeps the last size structured records at
+// full verbosity (anything at or below flushLevel) in a circular buffer and
+// only emits them, through klog, when Flush is called. This lets a build run
+// with terse logging on success but produce a detailed trace of the final
+// events leading up to a failure, without paying the formatting/I/O cost up
+// front. It is safe for concurrent use. size is clamped to a minimum of 1;
+// a non-positive size would otherwise leave the buffer empty and panic on
+// the first record.
+func RingBuffer(size int, flushLevel int) Logger {
+	if size < 1 {
+		size = 1
+	}
+	return &ring{
+		buf: &ringState{
+			entries:    make([]ringEntry, size),
+			flushLevel: flushLevel,
+		},
+	}
+}
+
+// ringState is the circular buffer shared by a ring Logger and every Logger
+// derived from it via V/WithValues/WithName.
+type ringState struct {
+	mu sync.Mutex
+
+	entries    []ringEntry
+	next       int
+	filled     bool
+	flushLevel int
+}
+
+// ring is a lightweight view (verbosity level, name, key/values) over a
+// shared ringState.
+type ring struct {
+	buf *ringState
+
+	v         int
+	name      string
+	keyValues []interface{}
+}
+
+func (r *ring) Is(level int) bool {
+	return level <= r.buf.flushLevel
+}
+
+func (r *ring) V(level int) Logger {
+	if level > r.buf.flushLevel {
+		return None
+	}
+	clone := *r
+	clone.v = level
+	return &clone
+}
+
+func (r *ring) Infof(format string, args ...interface{}) {
+	r.record(false, nil, fmt.Sprintf(format, args...))
+}
+
+func (r *ring) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	infofCtx(r, ctx, format, args...)
+}
+
+func (r *ring) InfoS(msg string, keysAndValues ...interface{}) {
+	r.record(false, nil, msg, keysAndValues...)
+}
+
+func (r *ring) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	r.record(true, err, msg, keysAndValues...)
+}
+
+func (r *ring) record(isError bool, err error, msg string, keysAndValues ...interface{}) {
+	if r.name != "" {
+		msg = r.name + ": " + msg
+	}
+	entry := ringEntry{
+		ts:      time.Now(),
+		level:   r.v,
+		isError: isError,
+		err:     err,
+		msg:     msg,
+		kv:      append(append([]interface{}{}, r.keyValues...), keysAndValues...),
+	}
+
+	b := r.buf
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = entry
+	b.next++
+	if b.next == len(b.entries) {
+		b.next = 0
+		b.filled = true
+	}
+}
+
+// Flush writes every buffered record, oldest first, through klog and empties
+// the buffer.
+func (r *ring) Flush() {
+	b := r.buf
+	b.mu.Lock()
+	var ordered []ringEntry
+	if b.filled {
+		ordered = append(ordered, b.entries[b.next:]...)
+	}
+	ordered = append(ordered, b.entries[:b.next]...)
+	b.next = 0
+	b.filled = false
+	b.mu.Unlock()
+
+	for _, entry := range ordered {
+		if entry.isError {
+			klog.ErrorSDepth(1, entry.err, entry.msg, entry.kv...)
+		} else {
+			klog.InfoSDepth(1, entry.msg, entry.kv...)
+		}
+	}
+}
+
+func (r *ring) WithValues(keysAndValues ...interface{}) Logger {
+	clone := *r
+	clone.keyValues = append(append([]interface{}{}, r.keyValues...), keysAndValues...)
+	return &clone
+}
+
+func (r *ring) WithName(name string) Logger {
+	clone := *r
+	if r.name != "" {
+		clone.name = r.name + "." + name
+	} else {
+		clone.name = name
+	}
+	return &clone
+}
+
+// Tee returns a Logger that forwards every call to all of loggers. Is and V
+// report enabled if any child is enabled; disabled children are dropped from
+// the result of V so that later calls skip their formatting work.
+func Tee(loggers ...Logger) Logger {
+	return tee(loggers)
+}
+
+type tee []Logger
+
+func (t tee) Is(level int) bool {
+	for _, l := range t {
+		if l.Is(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t tee) V(level int) Logger {
+	var enabled tee
+	for _, l := range t {
+		if v := l.V(level); v.Is(level) {
+			enabled = append(enabled, v)
+		}
+	}
+	if len(enabled) == 0 {
+		return None
+	}
+	return enabled
+}
+
+func (t tee) Infof(format string, args ...interface{}) {
+	for _, l := range t {
+		l.Infof(format, args...)
+	}
+}
+
+func (t tee) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	for _, l := range t {
+		l.InfofCtx(ctx, format, args...)
+	}
+}
+
+func (t tee) InfoS(msg string, keysAndValues ...interface{}) {
+	for _, l := range t {
+		l.InfoS(msg, keysAndValues...)
+	}
+}
+
+func (t tee) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	for _, l := range t {
+		l.ErrorS(err, msg, keysAndValues...)
+	}
+}
+
+func (t tee) WithValues(keysAndValues ...interface{}) Logger {
+	next := make(tee, len(t))
+	for i, l := range t {
+		next[i] = l.WithValues(keysAndValues...)
+	}
+	return next
+}
+
+func (t tee) WithName(name string) Logger {
+	next := make(tee, len(t))
+	for i, l := range t {
+		next[i] = l.WithName(name)
+	}
+	return next
+}
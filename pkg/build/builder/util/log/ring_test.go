@@ -0,0 +1,145 @@
+package log
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s.io/klog/v2"
+)
+
+// captureKlog redirects klog output to a buffer for the duration of the
+// test and restores it on cleanup.
+func captureKlog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	klog.SetOutput(&buf)
+	klog.LogToStderr(false)
+	t.Cleanup(func() {
+		klog.SetOutput(nil)
+		klog.LogToStderr(true)
+	})
+	return &buf
+}
+
+var klogFlagsOnce sync.Once
+
+// setKlogV raises the global klog verbosity for the duration of the test, so
+// that loggers gated on klog.V (as file.V is) are actually enabled.
+func setKlogV(t *testing.T, level string) {
+	t.Helper()
+	klogFlagsOnce.Do(func() { klog.InitFlags(nil) })
+	if err := flag.Set("v", level); err != nil {
+		t.Fatalf("failed to set klog -v=%s: %v", level, err)
+	}
+	t.Cleanup(func() { flag.Set("v", "0") })
+}
+
+func TestRingBufferWrapAroundAndFlushOrder(t *testing.T) {
+	buf := captureKlog(t)
+
+	rb := RingBuffer(3, 9)
+	for i := 0; i < 5; i++ {
+		rb.V(9).InfoS("tick", "i", i)
+	}
+
+	flusher, ok := rb.(Flusher)
+	if !ok {
+		t.Fatalf("RingBuffer() does not implement Flusher")
+	}
+	flusher.Flush()
+	klog.Flush()
+
+	out := buf.String()
+	iEarly, iLate := strings.Index(out, "i=2"), strings.Index(out, "i=4")
+	if strings.Contains(out, "i=0") || strings.Contains(out, "i=1") {
+		t.Errorf("Flush() output retained entries evicted by wraparound: %s", out)
+	}
+	if iEarly == -1 || iLate == -1 {
+		t.Fatalf("Flush() output missing expected entries: %s", out)
+	}
+	if iEarly > iLate {
+		t.Errorf("Flush() emitted entries out of order (want oldest first): %s", out)
+	}
+}
+
+func TestRingBufferNonPositiveSizeIsClamped(t *testing.T) {
+	buf := captureKlog(t)
+
+	for _, size := range []int{0, -1, -100} {
+		rb := RingBuffer(size, 9)
+		rb.V(9).InfoS("should not panic", "size", size)
+		rb.(Flusher).Flush()
+	}
+	klog.Flush()
+	if !strings.Contains(buf.String(), "should not panic") {
+		t.Errorf("expected a clamped, 1-entry buffer to still record and flush: %s", buf.String())
+	}
+}
+
+func TestRingBufferFlushEmptiesBuffer(t *testing.T) {
+	buf := captureKlog(t)
+
+	rb := RingBuffer(3, 9)
+	rb.V(9).InfoS("first flush")
+	flusher := rb.(Flusher)
+	flusher.Flush()
+	klog.Flush()
+	if !strings.Contains(buf.String(), "first flush") {
+		t.Fatalf("expected first Flush() to emit the buffered entry")
+	}
+
+	buf.Reset()
+	flusher.Flush()
+	klog.Flush()
+	if buf.Len() != 0 {
+		t.Errorf("second Flush() with nothing new recorded emitted %q, want empty", buf.String())
+	}
+}
+
+func TestRingBufferRespectsFlushLevel(t *testing.T) {
+	rb := RingBuffer(3, 2)
+	if rb.Is(3) {
+		t.Errorf("Is(3) = true, want false: flushLevel is 2")
+	}
+	if v := rb.V(3); v != None {
+		t.Errorf("V(3) = %v, want None: flushLevel is 2", v)
+	}
+}
+
+func TestTeeFansOutToAllLoggers(t *testing.T) {
+	var fileBuf bytes.Buffer
+	f := ToFile(&fileBuf, 5)
+	rb := RingBuffer(3, 9)
+
+	combined := Tee(f, rb)
+	combined.Infof("hello")
+
+	if !strings.Contains(fileBuf.String(), "hello") {
+		t.Errorf("Tee did not forward Infof to the file logger: %q", fileBuf.String())
+	}
+
+	buf := captureKlog(t)
+	rb.(Flusher).Flush()
+	klog.Flush()
+	if !strings.Contains(buf.String(), "hello") {
+		t.Errorf("Tee did not forward Infof to the ring buffer: %q", buf.String())
+	}
+}
+
+func TestTeeVDropsDisabledChildren(t *testing.T) {
+	setKlogV(t, "5")
+
+	rb := RingBuffer(3, 2)
+	var fileBuf bytes.Buffer
+	f := ToFile(&fileBuf, 9)
+
+	combined := Tee(f, rb).V(5)
+	combined.Infof("only file should see this")
+
+	if !strings.Contains(fileBuf.String(), "only file should see this") {
+		t.Errorf("expected the enabled child to still receive Infof: %q", fileBuf.String())
+	}
+}
@@ -0,0 +1,180 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"k8s.io/klog/v2"
+)
+
+// vPattern is a single "glob=level" entry of a vmodule spec.
+type vPattern struct {
+	glob  string
+	level int
+}
+
+var (
+	vmoduleMu       sync.RWMutex
+	vmodulePatterns []vPattern
+)
+
+// SetVModule installs a klog vmodule-style verbosity spec, e.g.
+// "dockerclient=5,imageprogress=2", that overrides the global -v level for
+// loggers created with Named. Patterns are matched against the subsystem name
+// with path.Match semantics (so "s2i/*=4" matches "s2i/tar"); the first
+// matching pattern wins. An empty spec clears all overrides.
+func SetVModule(spec string) error {
+	var patterns []vPattern
+	spec = strings.TrimSpace(spec)
+	if spec != "" {
+		for _, entry := range strings.Split(spec, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			parts := strings.SplitN(entry, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid vmodule entry %q: expected glob=level", entry)
+			}
+			level, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return fmt.Errorf("invalid vmodule entry %q: %v", entry, err)
+			}
+			glob := strings.TrimSpace(parts[0])
+			if _, err := path.Match(glob, ""); err != nil {
+				return fmt.Errorf("invalid vmodule entry %q: %v", entry, err)
+			}
+			patterns = append(patterns, vPattern{glob: glob, level: level})
+		}
+	}
+
+	vmoduleMu.Lock()
+	vmodulePatterns = patterns
+	vmoduleMu.Unlock()
+	return nil
+}
+
+// lookupVModule returns the vmodule override level for subsystem, if any.
+func lookupVModule(subsystem string) (int, bool) {
+	vmoduleMu.RLock()
+	defer vmoduleMu.RUnlock()
+	for _, p := range vmodulePatterns {
+		if ok, _ := path.Match(p.glob, subsystem); ok {
+			return p.level, true
+		}
+	}
+	return 0, false
+}
+
+// Named returns a Logger scoped to subsystem (e.g. "dockerclient", "s2i/tar")
+// whose verbosity gating consults the vmodule table installed by SetVModule
+// before falling back to the global klog -v level, so individual subsystems
+// can be cranked up independently at runtime.
+func Named(subsystem string) Logger {
+	return named{subsystem: subsystem, inner: Log.WithName(subsystem)}
+}
+
+// named gates Is/V by the vmodule table, delegating everything else to inner.
+type named struct {
+	subsystem string
+	inner     Logger
+}
+
+func (n named) Is(level int) bool {
+	if vlevel, ok := lookupVModule(n.subsystem); ok {
+		return level <= vlevel
+	}
+	return n.inner.Is(level)
+}
+
+func (n named) V(level int) Logger {
+	if vlevel, ok := lookupVModule(n.subsystem); ok {
+		if level <= vlevel {
+			return forcedVerbose{subsystem: n.subsystem, inner: n.inner}
+		}
+		return None
+	}
+	return n.inner.V(level)
+}
+
+func (n named) Infof(format string, args ...interface{}) { n.inner.Infof(format, args...) }
+func (n named) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	infofCtx(n, ctx, format, args...)
+}
+func (n named) InfoS(msg string, keysAndValues ...interface{}) { n.inner.InfoS(msg, keysAndValues...) }
+func (n named) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	n.inner.ErrorS(err, msg, keysAndValues...)
+}
+func (n named) WithValues(keysAndValues ...interface{}) Logger {
+	return named{subsystem: n.subsystem, inner: n.inner.WithValues(keysAndValues...)}
+}
+func (n named) WithName(name string) Logger {
+	return named{subsystem: n.subsystem, inner: n.inner.WithName(name)}
+}
+
+// forcedVerbose logs through inner at a level the vmodule table has already
+// decided is enabled regardless of the global klog level. It re-consults the
+// table on every Is/V call, rather than freezing the decision, so a further
+// V() in the chain (as Tee and RateLimited both perform on their children)
+// is checked against the requested level instead of staying enabled forever.
+type forcedVerbose struct {
+	subsystem string
+	inner     Logger
+}
+
+func (f forcedVerbose) Is(level int) bool {
+	if vlevel, ok := lookupVModule(f.subsystem); ok {
+		return level <= vlevel
+	}
+	return f.inner.Is(level)
+}
+
+func (f forcedVerbose) V(level int) Logger {
+	if vlevel, ok := lookupVModule(f.subsystem); ok {
+		if level <= vlevel {
+			return forcedVerbose{subsystem: f.subsystem, inner: f.inner}
+		}
+		return None
+	}
+	return f.inner.V(level)
+}
+func (f forcedVerbose) Infof(format string, args ...interface{}) { f.inner.Infof(format, args...) }
+func (f forcedVerbose) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	infofCtx(f, ctx, format, args...)
+}
+func (f forcedVerbose) InfoS(msg string, keysAndValues ...interface{}) {
+	f.inner.InfoS(msg, keysAndValues...)
+}
+func (f forcedVerbose) ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	f.inner.ErrorS(err, msg, keysAndValues...)
+}
+func (f forcedVerbose) WithValues(keysAndValues ...interface{}) Logger {
+	return forcedVerbose{subsystem: f.subsystem, inner: f.inner.WithValues(keysAndValues...)}
+}
+func (f forcedVerbose) WithName(name string) Logger {
+	return forcedVerbose{subsystem: f.subsystem, inner: f.inner.WithName(name)}
+}
+
+// WatchVModuleSignal installs a SIGUSR2 handler that re-parses the vmodule
+// spec from the given environment variable and installs it via SetVModule,
+// so operators can bump verbosity on a running long build without
+// restarting it. It returns immediately; the handler runs in a background
+// goroutine for the lifetime of the process.
+func WatchVModuleSignal(envVar string) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR2)
+	go func() {
+		for range ch {
+			if err := SetVModule(os.Getenv(envVar)); err != nil {
+				klog.Errorf("invalid %s: %v", envVar, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,117 @@
+package log
+
+import "testing"
+
+func resetVModule(t *testing.T) {
+	t.Helper()
+	if err := SetVModule(""); err != nil {
+		t.Fatalf("failed to reset vmodule table: %v", err)
+	}
+}
+
+func TestSetVModuleInvalidSpec(t *testing.T) {
+	defer resetVModule(t)
+	cases := []string{
+		"nolevel",
+		"glob=notanumber",
+		"[invalid=5",
+	}
+	for _, spec := range cases {
+		if err := SetVModule(spec); err == nil {
+			t.Errorf("SetVModule(%q) = nil, want an error", spec)
+		}
+	}
+}
+
+func TestLookupVModule(t *testing.T) {
+	defer resetVModule(t)
+	if err := SetVModule("dockerclient=5,s2i/*=4"); err != nil {
+		t.Fatalf("SetVModule: %v", err)
+	}
+
+	cases := []struct {
+		subsystem string
+		wantLevel int
+		wantOK    bool
+	}{
+		{"dockerclient", 5, true},
+		{"s2i/tar", 4, true},
+		{"s2i/download", 4, true},
+		{"imageprogress", 0, false},
+	}
+	for _, c := range cases {
+		level, ok := lookupVModule(c.subsystem)
+		if ok != c.wantOK || (ok && level != c.wantLevel) {
+			t.Errorf("lookupVModule(%q) = (%d, %v), want (%d, %v)", c.subsystem, level, ok, c.wantLevel, c.wantOK)
+		}
+	}
+}
+
+func TestLookupVModuleFirstMatchWins(t *testing.T) {
+	defer resetVModule(t)
+	if err := SetVModule("dockerclient=2,dockerclient=9"); err != nil {
+		t.Fatalf("SetVModule: %v", err)
+	}
+	level, ok := lookupVModule("dockerclient")
+	if !ok || level != 2 {
+		t.Errorf("lookupVModule(\"dockerclient\") = (%d, %v), want (2, true) for the first matching pattern", level, ok)
+	}
+}
+
+func TestSetVModuleEmptyClearsOverrides(t *testing.T) {
+	defer resetVModule(t)
+	if err := SetVModule("dockerclient=5"); err != nil {
+		t.Fatalf("SetVModule: %v", err)
+	}
+	if _, ok := lookupVModule("dockerclient"); !ok {
+		t.Fatalf("expected an override to be installed")
+	}
+	if err := SetVModule(""); err != nil {
+		t.Fatalf("SetVModule(\"\"): %v", err)
+	}
+	if _, ok := lookupVModule("dockerclient"); ok {
+		t.Errorf("expected empty spec to clear all overrides")
+	}
+}
+
+func TestNamedConsultsVModuleBeforeGlobalLevel(t *testing.T) {
+	defer resetVModule(t)
+	if err := SetVModule("dockerclient=5"); err != nil {
+		t.Fatalf("SetVModule: %v", err)
+	}
+
+	overridden := Named("dockerclient")
+	if !overridden.Is(5) {
+		t.Errorf("Named(\"dockerclient\").Is(5) = false, want true: vmodule override should win regardless of the global klog level")
+	}
+	if !overridden.V(5).Is(5) {
+		t.Errorf("Named(\"dockerclient\").V(5).Is(5) = false, want true")
+	}
+
+	notOverridden := Named("imageprogress")
+	if notOverridden.Is(5) {
+		t.Errorf("Named(\"imageprogress\").Is(5) = true, want false: no override installed and the global klog level is 0 in tests")
+	}
+}
+
+// TestForcedVerboseRechecksLevelOnRepeatedV is a regression test: chaining
+// V() more than once on a logger that already matched a vmodule override
+// (as Tee and RateLimited both do to their children) must re-check the new
+// level against the override, not stay "forced enabled" forever.
+func TestForcedVerboseRechecksLevelOnRepeatedV(t *testing.T) {
+	defer resetVModule(t)
+	if err := SetVModule("dockerclient=5"); err != nil {
+		t.Fatalf("SetVModule: %v", err)
+	}
+
+	once := Named("dockerclient").V(5)
+	if !once.Is(5) {
+		t.Fatalf("Named(\"dockerclient\").V(5).Is(5) = false, want true")
+	}
+	if twice := once.V(9); twice.Is(9) {
+		t.Errorf("Named(\"dockerclient\").V(5).V(9).Is(9) = true, want false: 9 exceeds the dockerclient=5 override")
+	}
+	if twice := once.V(3); !twice.Is(3) {
+		t.Errorf("Named(\"dockerclient\").V(5).V(3).Is(3) = false, want true: 3 is within the dockerclient=5 override")
+	}
+}